@@ -0,0 +1,170 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bufio"
+	"container/heap"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"strings"
+)
+
+// approxOptions configures -approx mode: a Count-Min Sketch sized from epsilon/delta, paired
+// with a size-k heavy-hitter heap that tracks the k lines with the largest estimated counts.
+type approxOptions struct {
+	k       int
+	epsilon float64
+	delta   float64
+}
+
+// countMinSketch is a probabilistic frequency counter that estimates how many times each
+// key has been seen in O(w*d) memory regardless of how many distinct keys there are, at the
+// cost of possible over-counting: every estimate is >= the true count, and is within
+// epsilon*N of it with probability 1-delta (N being the total number of items added).
+type countMinSketch struct {
+	rows [][]int64
+	w    int
+	d    int
+}
+
+// newCountMinSketch sizes a sketch from the standard Count-Min construction:
+// w = ceil(e/epsilon) counters per row, d = ceil(ln(1/delta)) rows.
+func newCountMinSketch(epsilon, delta float64) *countMinSketch {
+	w := int(math.Ceil(math.E / epsilon))
+	d := int(math.Ceil(math.Log(1 / delta)))
+
+	rows := make([][]int64, d)
+	for i := range rows {
+		rows[i] = make([]int64, w)
+	}
+	return &countMinSketch{rows: rows, w: w, d: d}
+}
+
+// add increments the sketch's counters for key and returns its updated estimated count,
+// the minimum across the d rows after incrementing.
+func (s *countMinSketch) add(key string) int64 {
+	h1, h2 := sketchHashes(key)
+	est := int64(math.MaxInt64)
+	for i := 0; i < s.d; i++ {
+		idx := (h1 + uint64(i)*h2) % uint64(s.w)
+		s.rows[i][idx]++
+		if s.rows[i][idx] < est {
+			est = s.rows[i][idx]
+		}
+	}
+	return est
+}
+
+// sketchHashes derives the two independent 64-bit hashes combined by add into the sketch's
+// per-row indices: an FNV-1a hash of key, and an FNV-1a hash of a salted variant of key.
+func sketchHashes(key string) (uint64, uint64) {
+	h1 := fnv.New64a()
+	h1.Write([]byte(key))
+
+	h2 := fnv.New64a()
+	h2.Write([]byte(key))
+	h2.Write([]byte{0})
+
+	v2 := h2.Sum64()
+	if v2 == 0 {
+		v2 = 1 // a zero stride would pin every row to index h1 mod w
+	}
+	return h1.Sum64(), v2
+}
+
+// heavyHitterHeap is a size-bounded min-heap over estimated counts: the k lines with the
+// largest estimate observed so far. index maps a tracked key to its slot, so a repeat key's
+// entry can be found and updated in O(log k) instead of scanning the heap.
+type heavyHitterHeap struct {
+	items []item
+	index map[string]int
+	k     int
+}
+
+func newHeavyHitterHeap(k int) *heavyHitterHeap {
+	return &heavyHitterHeap{items: make([]item, 0, k), index: make(map[string]int, k), k: k}
+}
+
+func (h *heavyHitterHeap) Len() int           { return len(h.items) }
+func (h *heavyHitterHeap) Less(i, j int) bool { return h.items[i].count < h.items[j].count }
+func (h *heavyHitterHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.index[h.items[i].value] = i
+	h.index[h.items[j].value] = j
+}
+
+func (h *heavyHitterHeap) Push(x any) {
+	it := x.(item)
+	h.index[it.value] = len(h.items)
+	h.items = append(h.items, it)
+}
+
+func (h *heavyHitterHeap) Pop() any {
+	old := h.items
+	n := len(old)
+	it := old[n-1]
+	delete(h.index, it.value)
+	h.items = old[:n-1]
+	return it
+}
+
+// observe records a fresh estimated count for value: an already-tracked key is updated in
+// place; a new key is inserted if there's room, or if its estimate beats the weakest
+// currently-tracked entry, which is evicted to make room.
+func (h *heavyHitterHeap) observe(value string, count int64) {
+	if idx, ok := h.index[value]; ok {
+		h.items[idx].count = int(count)
+		heap.Fix(h, idx)
+		return
+	}
+	if h.Len() < h.k {
+		heap.Push(h, item{value, int(count)})
+		return
+	}
+	if int(count) > h.items[0].count {
+		delete(h.index, h.items[0].value)
+		h.items[0] = item{value, int(count)}
+		h.index[value] = 0
+		heap.Fix(h, 0)
+	}
+}
+
+// scanCountsApprox reads every line from input like scanCounts, but counts frequencies with
+// a Count-Min Sketch instead of an exact map and tracks only the opts.k heaviest hitters
+// seen so far, so memory stays O(k + w*d) regardless of the number of distinct lines. The
+// returned counts are upper-bound estimates, not exact frequencies.
+func scanCountsApprox(input io.Reader, ignoreCase bool, filter *filterOptions, opts approxOptions) map[string]int {
+	sketch := newCountMinSketch(opts.epsilon, opts.delta)
+	hitters := newHeavyHitterHeap(opts.k)
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(make([]byte, maxLineLength), maxLineLength)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) > maxLineLength {
+			exitWithError(fmt.Errorf("Line exceeds maximum length of %d characters", maxLineLength))
+		}
+		key, keep := filter.apply(line)
+		if !keep {
+			continue
+		}
+		if ignoreCase {
+			key = strings.ToLower(key)
+		}
+		hitters.observe(key, sketch.add(key))
+	}
+
+	if err := scanner.Err(); err != nil {
+		exitWithError(fmt.Errorf("Error reading input: %v", err))
+	}
+
+	counts := make(map[string]int, hitters.Len())
+	for _, it := range hitters.items {
+		counts[it.value] = it.count
+	}
+	return counts
+}
@@ -0,0 +1,161 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCountMinSketchNeverUndercounts(t *testing.T) {
+	sketch := newCountMinSketch(0.01, 0.01)
+	counts := make(map[string]int)
+
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		key := fmt.Sprintf("key%d", r.Intn(200))
+		counts[key]++
+		if est := sketch.add(key); int(est) < counts[key] {
+			t.Fatalf("sketch.add(%q) = %d, want >= running true count %d", key, est, counts[key])
+		}
+	}
+
+	for key, want := range counts {
+		if got := sketch.add(key) - 1; int(got) < want {
+			t.Errorf("final estimate for %q = %d, want >= true count %d", key, got, want)
+		}
+	}
+}
+
+func TestHeavyHitterHeapEvictsWeakest(t *testing.T) {
+	h := newHeavyHitterHeap(2)
+	h.observe("a", 5)
+	h.observe("b", 3)
+	h.observe("c", 1) // weaker than both tracked entries, should be dropped
+
+	if h.Len() != 2 {
+		t.Fatalf("heap length = %d, want 2", h.Len())
+	}
+	if _, ok := h.index["c"]; ok {
+		t.Errorf("heap tracked %q, want it evicted as the weakest candidate", "c")
+	}
+
+	h.observe("b", 10) // update in place, now the strongest entry
+	h.observe("d", 4)  // weaker than the current floor (a at 5), should be dropped
+
+	if _, ok := h.index["d"]; ok {
+		t.Errorf("heap tracked %q with a weaker estimate than the current floor", "d")
+	}
+	if h.items[h.index["b"]].count != 10 {
+		t.Errorf("updated count for %q = %d, want 10", "b", h.items[h.index["b"]].count)
+	}
+}
+
+// zipfLines generates n lines drawn from a Zipf distribution over v distinct values,
+// returning the lines in draw order along with their true frequency counts.
+func zipfLines(n, v int, seed int64) ([]string, map[string]int) {
+	r := rand.New(rand.NewSource(seed))
+	z := rand.NewZipf(r, 1.5, 1, uint64(v-1))
+
+	lines := make([]string, n)
+	counts := make(map[string]int, v)
+	for i := 0; i < n; i++ {
+		key := fmt.Sprintf("key%03d", z.Uint64())
+		lines[i] = key
+		counts[key]++
+	}
+	return lines, counts
+}
+
+// TestScanCountsApproxFindsZipfHeavyHitters feeds a known Zipf distribution through
+// scanCountsApprox and checks that every true top-K line is reported, with an estimate
+// that's an upper bound on its true count and within epsilon*N of it.
+func TestScanCountsApproxFindsZipfHeavyHitters(t *testing.T) {
+	const n = 20000
+	const v = 100
+	const k = 5
+
+	lines, counts := zipfLines(n, v, 1)
+
+	var trueTop []item
+	for value, count := range counts {
+		trueTop = append(trueTop, item{value, count})
+	}
+	sortItems(trueTop)
+	trueTop = trueTop[:k]
+
+	opts := approxOptions{k: k, epsilon: 0.001, delta: 0.001}
+	input := strings.NewReader(strings.Join(lines, "\n") + "\n")
+	got := scanCountsApprox(input, false, nil, opts)
+
+	tolerance := int(opts.epsilon*float64(n)) + 1
+	for _, want := range trueTop {
+		gotCount, ok := got[want.value]
+		if !ok {
+			t.Errorf("scanCountsApprox() missing true heavy hitter %q (true count %d), got %v", want.value, want.count, got)
+			continue
+		}
+		if gotCount < want.count {
+			t.Errorf("scanCountsApprox() count for %q = %d, want >= true count %d (estimates are upper bounds)", want.value, gotCount, want.count)
+		}
+		if gotCount > want.count+tolerance {
+			t.Errorf("scanCountsApprox() count for %q = %d, want within %d of true count %d", want.value, gotCount, tolerance, want.count)
+		}
+	}
+}
+
+func TestValidateApproxFlags(t *testing.T) {
+	tests := []struct {
+		name           string
+		high, low      int
+		lowPercent     float64
+		epsilon, delta float64
+		aggregate      bool
+		wantErr        bool
+	}{
+		{"valid", 5, 0, 0, 0.001, 0.001, false, false},
+		{"no high", 0, 0, 0, 0.001, 0.001, false, true},
+		{"low set", 5, 1, 0, 0.001, 0.001, false, true},
+		{"lp set", 5, 0, 10, 0.001, 0.001, false, true},
+		{"non-positive epsilon", 5, 0, 0, 0, 0.001, false, true},
+		{"epsilon too large", 5, 0, 0, 1, 0.001, false, true},
+		{"non-positive delta", 5, 0, 0, 0.001, 0, false, true},
+		{"delta too large", 5, 0, 0, 0.001, 1, false, true},
+		{"aggregate set", 5, 0, 0, 0.001, 0.001, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateApproxFlags(tt.high, tt.low, tt.lowPercent, tt.epsilon, tt.delta, tt.aggregate)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateApproxFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestProcessInputsApproxAggregateWouldLoseHeavyHitters documents the bug -approx -aggregate
+// validation now rejects: naively summing each input's independently-bounded top-K map
+// drops a heavy hitter that was the true aggregate winner but didn't make every individual
+// input's cut. Five inputs each contribute "x" twice plus one input-local item at count 3;
+// the true aggregate top-1 is "x" (10), but bounding each input to -high 1 before summing
+// reports a file-local count-3 item instead, having discarded "x" from every input's result.
+func TestProcessInputsApproxAggregateWouldLoseHeavyHitters(t *testing.T) {
+	opts := approxOptions{k: 1, epsilon: 0.001, delta: 0.001}
+
+	aggregateCounts := make(map[string]int)
+	for i := 0; i < 5; i++ {
+		text := fmt.Sprintf("x\nx\nunique%d\nunique%d\nunique%d\n", i, i, i)
+		fileCounts := scanCountsApprox(strings.NewReader(text), false, nil, opts)
+		for key, count := range fileCounts {
+			aggregateCounts[key] += count
+		}
+	}
+
+	if _, ok := aggregateCounts["x"]; ok {
+		t.Fatalf("aggregateCounts = %v; if \"x\" survives, the -approx -aggregate rejection above is no longer needed and should be replaced with a real fix", aggregateCounts)
+	}
+}
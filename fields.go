@@ -0,0 +1,127 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// filterOptions bundles field-selection and regex pre-filtering for the scanner loop
+// shared by scanCounts and countChunks. A nil *filterOptions, the default when none of
+// -fields, -grep, or -vgrep is set, costs nothing extra per line.
+type filterOptions struct {
+	fields []int            // sorted, 1-based field indices to keep and join; empty means "whole line"
+	delim  string           // field delimiter; empty means split on runs of whitespace
+	grep   []*regexp.Regexp // line must match all of these to survive
+	vgrep  []*regexp.Regexp // line must match none of these to survive
+}
+
+// apply runs line through the filter's pre-filter regexps and then field selection, in
+// that order, returning the resulting counting key and whether the line survives at all.
+func (f *filterOptions) apply(line string) (string, bool) {
+	if f == nil {
+		return line, true
+	}
+	for _, re := range f.grep {
+		if !re.MatchString(line) {
+			return "", false
+		}
+	}
+	for _, re := range f.vgrep {
+		if re.MatchString(line) {
+			return "", false
+		}
+	}
+	if len(f.fields) > 0 {
+		line = selectFields(line, f.fields, f.delim)
+	}
+	return line, true
+}
+
+// selectFields splits line on delim (or runs of whitespace when delim is empty) and joins
+// the requested 1-based fields, in the order given, with a single space. Indices past the
+// end of the line are skipped.
+func selectFields(line string, fields []int, delim string) string {
+	var parts []string
+	if delim == "" {
+		parts = strings.Fields(line)
+	} else {
+		parts = strings.Split(line, delim)
+	}
+
+	selected := make([]string, 0, len(fields))
+	for _, idx := range fields {
+		if idx >= 1 && idx <= len(parts) {
+			selected = append(selected, parts[idx-1])
+		}
+	}
+	return strings.Join(selected, " ")
+}
+
+// parseFieldSpec parses a field specification such as "1,3-4,7" into a sorted slice of
+// unique 1-based field indices.
+func parseFieldSpec(spec string) ([]int, error) {
+	seen := make(map[int]bool)
+	var fields []int
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		start, end, isRange := strings.Cut(part, "-")
+		lo, err := strconv.Atoi(strings.TrimSpace(start))
+		if err != nil || lo < 1 {
+			return nil, fmt.Errorf("invalid field spec %q: %q is not a positive integer", spec, start)
+		}
+		hi := lo
+		if isRange {
+			hi, err = strconv.Atoi(strings.TrimSpace(end))
+			if err != nil || hi < lo {
+				return nil, fmt.Errorf("invalid field spec %q: range %q is invalid", spec, part)
+			}
+		}
+
+		for i := lo; i <= hi; i++ {
+			if !seen[i] {
+				seen[i] = true
+				fields = append(fields, i)
+			}
+		}
+	}
+
+	sort.Ints(fields)
+	return fields, nil
+}
+
+// regexpList implements flag.Value so -grep and -vgrep can each be passed multiple times,
+// compiling every pattern once at startup.
+type regexpList struct {
+	patterns []*regexp.Regexp
+}
+
+func (r *regexpList) String() string {
+	if r == nil || len(r.patterns) == 0 {
+		return ""
+	}
+	parts := make([]string, len(r.patterns))
+	for i, re := range r.patterns {
+		parts[i] = re.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+func (r *regexpList) Set(value string) error {
+	re, err := regexp.Compile(value)
+	if err != nil {
+		return fmt.Errorf("invalid regexp %q: %v", value, err)
+	}
+	r.patterns = append(r.patterns, re)
+	return nil
+}
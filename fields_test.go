@@ -0,0 +1,146 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParseFieldSpec(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []int
+		wantErr bool
+	}{
+		{"single field", "3", []int{3}, false},
+		{"multiple fields", "1,3", []int{1, 3}, false},
+		{"range", "3-5", []int{3, 4, 5}, false},
+		{"mixed with range", "1,3-4,7", []int{1, 3, 4, 7}, false},
+		{"unsorted input stays sorted", "7,1,3", []int{1, 3, 7}, false},
+		{"duplicates collapse", "1,1,2-3,3", []int{1, 2, 3}, false},
+		{"empty spec", "", nil, false},
+		{"non-numeric", "a", nil, true},
+		{"zero field", "0", nil, true},
+		{"inverted range", "5-3", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFieldSpec(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFieldSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFieldSpec(%q) = %v, want %v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		fields []int
+		delim  string
+		want   string
+	}{
+		{"single field whitespace", "GET /foo 200 12ms", []int{2}, "", "/foo"},
+		{"multi field whitespace", "GET /foo 200 12ms", []int{1, 3}, "", "GET 200"},
+		{"range", "a b c d e", []int{2, 3, 4}, "", "b c d"},
+		{"out of range index skipped", "a b", []int{1, 5}, "", "a"},
+		{"custom delimiter", "a,b,c", []int{1, 3}, ",", "a c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectFields(tt.line, tt.fields, tt.delim); got != tt.want {
+				t.Errorf("selectFields(%q, %v, %q) = %q, want %q", tt.line, tt.fields, tt.delim, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterOptionsApply(t *testing.T) {
+	nilFilter := (*filterOptions)(nil)
+	if key, keep := nilFilter.apply("anything"); key != "anything" || !keep {
+		t.Errorf("nil filter apply() = (%q, %v), want (\"anything\", true)", key, keep)
+	}
+
+	fields, err := parseFieldSpec("1,3-4")
+	if err != nil {
+		t.Fatalf("parseFieldSpec() error = %v", err)
+	}
+	f := &filterOptions{
+		fields: fields,
+		grep:   []*regexp.Regexp{regexp.MustCompile(`ERROR`)},
+		vgrep:  []*regexp.Regexp{regexp.MustCompile(`ignore-me`)},
+	}
+
+	if key, keep := f.apply("2024 ERROR db timeout ignore-me"); keep {
+		t.Errorf("apply() kept a vgrep-matching line, got key %q", key)
+	}
+	if _, keep := f.apply("2024 INFO db ok"); keep {
+		t.Errorf("apply() kept a line that doesn't match grep")
+	}
+	key, keep := f.apply("2024 ERROR db timeout")
+	if !keep {
+		t.Fatalf("apply() dropped a line that should have survived")
+	}
+	if want := "2024 db timeout"; key != want {
+		t.Errorf("apply() key = %q, want %q", key, want)
+	}
+}
+
+// TestScanCountsWithFilter exercises the full scanner loop (analogous to TestProcessInput)
+// with field selection and combined grep/vgrep filtering.
+func TestScanCountsWithFilter(t *testing.T) {
+	input := strings.NewReader(strings.Join([]string{
+		"2024-01-01 ERROR auth timeout",
+		"2024-01-01 INFO auth ok",
+		"2024-01-02 ERROR auth timeout",
+		"2024-01-02 ERROR db retrying",
+		"2024-01-03 ERROR auth skip-me",
+	}, "\n") + "\n")
+
+	fields, err := parseFieldSpec("2,3-4")
+	if err != nil {
+		t.Fatalf("parseFieldSpec() error = %v", err)
+	}
+	filter := &filterOptions{
+		fields: fields,
+		grep:   []*regexp.Regexp{regexp.MustCompile(`ERROR`)},
+		vgrep:  []*regexp.Regexp{regexp.MustCompile(`skip-me`)},
+	}
+
+	got := scanCounts(input, false, filter)
+	want := map[string]int{
+		"ERROR auth timeout": 2,
+		"ERROR db retrying":  1,
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("scanCounts() with filter = %v, want %v", got, want)
+	}
+}
+
+func TestRegexpListFlag(t *testing.T) {
+	var list regexpList
+	if err := list.Set(`foo\d+`); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if err := list.Set(`bar`); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if len(list.patterns) != 2 {
+		t.Fatalf("len(patterns) = %d, want 2", len(list.patterns))
+	}
+	if err := list.Set(`(unclosed`); err == nil {
+		t.Errorf("Set() with invalid regexp expected error, got nil")
+	}
+}
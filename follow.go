@@ -0,0 +1,178 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// followOptions configures -follow mode: how often the display refreshes, and the optional
+// half-life used to decay old counts so a live view stays dominated by recent activity.
+type followOptions struct {
+	interval time.Duration
+	decay    time.Duration // 0 disables decay
+}
+
+// followBatchSize is how many lines the scanner goroutine accumulates before taking the
+// write lock to apply them to the shared counts map, trading a little display staleness for
+// less lock contention on a fast-moving stream. It's a var, like parallelChunkSize, so tests
+// can shrink it to see a flush without needing hundreds of lines of input.
+var followBatchSize = 100
+
+// followPollInterval is how often followReader retries after a real io.EOF from the
+// underlying reader, waiting for more data to arrive instead of ending the stream.
+var followPollInterval = 200 * time.Millisecond
+
+// runFollow counts lines from input the same way scanCounts does, except it never stops at
+// EOF: a scanner goroutine keeps reading (see followReader) while this goroutine wakes up
+// every opts.interval to decay, snapshot, and render the current top-N to out, clearing the
+// terminal first when out is one. It returns once ctx is canceled, after writing one final
+// frame with the ranking at that point, the behavior wanted when Ctrl-C stops a live
+// -follow session.
+func runFollow(ctx context.Context, input io.Reader, ignoreCase bool, filter *filterOptions, opts followOptions, sel Selection, formatter Formatter, out io.Writer) {
+	counts := make(map[string]int)
+	var mu sync.RWMutex
+
+	scanDone := make(chan struct{})
+	go func() {
+		defer close(scanDone)
+		scanFollow(ctx, input, ignoreCase, filter, &mu, counts)
+	}()
+
+	ticker := time.NewTicker(opts.interval)
+	defer ticker.Stop()
+
+	tty := isTerminal(out)
+	last := time.Now()
+	for {
+		select {
+		case now := <-ticker.C:
+			renderFollowFrame(&mu, counts, opts.decay, now.Sub(last), sel, formatter, out, tty)
+			last = now
+		case <-ctx.Done():
+			renderFollowFrame(&mu, counts, 0, 0, sel, formatter, out, tty)
+			<-scanDone
+			return
+		}
+	}
+}
+
+// renderFollowFrame applies decay (if any) to counts, snapshots it, and writes one frame to
+// out: a screen clear plus the current top-N when out is a terminal, or a plain separator
+// and reprint otherwise.
+func renderFollowFrame(mu *sync.RWMutex, counts map[string]int, decay, elapsed time.Duration, sel Selection, formatter Formatter, out io.Writer, tty bool) {
+	if decay > 0 && elapsed > 0 {
+		factor := math.Pow(0.5, elapsed.Seconds()/decay.Seconds())
+		mu.Lock()
+		for key, count := range counts {
+			if decayed := int(float64(count) * factor); decayed > 0 {
+				counts[key] = decayed
+			} else {
+				delete(counts, key)
+			}
+		}
+		mu.Unlock()
+	}
+
+	mu.RLock()
+	snapshot := make(map[string]int, len(counts))
+	for key, count := range counts {
+		snapshot[key] = count
+	}
+	mu.RUnlock()
+
+	if tty {
+		fmt.Fprint(out, "\x1b[2J\x1b[H")
+	} else {
+		fmt.Fprintln(out, "----")
+	}
+	formatter.Write(out, []inputResult{{name: "follow", counts: snapshot}}, sel)
+}
+
+// scanFollow is processInput's counting loop adapted for -follow: it reads from a
+// followReader wrapping input, which blocks across EOF instead of ending the stream, so
+// scanning only stops once ctx is canceled. Lines are counted into a local batch and applied
+// to the shared counts map under mu's write lock every followBatchSize lines.
+func scanFollow(ctx context.Context, input io.Reader, ignoreCase bool, filter *filterOptions, mu *sync.RWMutex, counts map[string]int) {
+	scanner := bufio.NewScanner(&followReader{ctx: ctx, r: input})
+	scanner.Buffer(make([]byte, maxLineLength), maxLineLength)
+
+	batch := make([]string, 0, followBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		mu.Lock()
+		for _, key := range batch {
+			counts[key]++
+		}
+		mu.Unlock()
+		batch = batch[:0]
+	}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if len(line) > maxLineLength {
+			exitWithError(fmt.Errorf("Line exceeds maximum length of %d characters", maxLineLength))
+		}
+		key, keep := filter.apply(line)
+		if !keep {
+			continue
+		}
+		if ignoreCase {
+			key = strings.ToLower(key)
+		}
+		batch = append(batch, key)
+		if len(batch) >= followBatchSize {
+			flush()
+		}
+	}
+	flush()
+}
+
+// followReader wraps an io.Reader so Read blocks across a real io.EOF, retrying every
+// followPollInterval until more data arrives, unless ctx is canceled, in which case it
+// returns io.EOF so the scanner stops cleanly. Reading from a pipe or stdin already blocks
+// this way; followReader extends the same behavior to readers (such as a growing file) that
+// return io.EOF as soon as they run dry.
+type followReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (f *followReader) Read(p []byte) (int, error) {
+	for {
+		n, err := f.r.Read(p)
+		if n > 0 || err != io.EOF {
+			return n, err
+		}
+		select {
+		case <-f.ctx.Done():
+			return 0, io.EOF
+		case <-time.After(followPollInterval):
+		}
+	}
+}
+
+// isTerminal reports whether w is a character device, the same check collectInputs uses for
+// stdin, so runFollow can choose between an ANSI screen clear and a plain reprint.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return stat.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,206 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countForKey extracts the "<count> <key>" line for key out of a TextFormatter frame, if
+// present.
+func countForKey(frame, key string) (int, bool) {
+	for _, line := range strings.Split(frame, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[1] == key {
+			count, err := strconv.Atoi(fields[0])
+			if err == nil {
+				return count, true
+			}
+		}
+	}
+	return 0, false
+}
+
+func TestRunFollowEmitsGrowingFrames(t *testing.T) {
+	oldBatch := followBatchSize
+	followBatchSize = 1
+	defer func() { followBatchSize = oldBatch }()
+
+	r, w := io.Pipe()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := followOptions{interval: 15 * time.Millisecond}
+	sel := Selection{High: 2, SortLimit: defaultSortLimit}
+
+	var out bytes.Buffer
+	var mu sync.Mutex // guards out against a frame racing the test's cancel/close sequence
+	syncOut := syncWriter{w: &out, mu: &mu}
+
+	done := make(chan struct{})
+	go func() {
+		runFollow(ctx, r, false, nil, opts, sel, TextFormatter{}, syncOut)
+		close(done)
+	}()
+
+	go func() {
+		for i := 0; i < 6; i++ {
+			fmt.Fprintf(w, "line%d\n", i%2)
+			time.Sleep(20 * time.Millisecond)
+		}
+	}()
+
+	time.Sleep(160 * time.Millisecond)
+	cancel()
+	w.Close()
+	r.Close()
+	<-done
+
+	mu.Lock()
+	output := out.String()
+	mu.Unlock()
+
+	frames := strings.Split(output, "----\n")
+	var nonEmpty []string
+	for _, f := range frames {
+		if strings.TrimSpace(f) != "" {
+			nonEmpty = append(nonEmpty, f)
+		}
+	}
+	if len(nonEmpty) < 2 {
+		t.Fatalf("runFollow() produced %d non-empty frames, want at least 2: %q", len(nonEmpty), output)
+	}
+
+	last := -1
+	for _, frame := range nonEmpty {
+		count, ok := countForKey(frame, "line0")
+		if !ok {
+			continue
+		}
+		if count < last {
+			t.Errorf("runFollow() count for %q went from %d to %d, want monotonically non-decreasing", "line0", last, count)
+		}
+		last = count
+	}
+	if last <= 0 {
+		t.Fatalf("runFollow() never reported a count for %q across frames: %q", "line0", output)
+	}
+}
+
+// syncWriter serializes writes to an underlying writer so the renderer goroutine and the
+// test goroutine reading its buffer afterward never race.
+type syncWriter struct {
+	w  io.Writer
+	mu *sync.Mutex
+}
+
+func (s syncWriter) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.w.Write(p)
+}
+
+func TestFollowReaderBlocksAcrossEOF(t *testing.T) {
+	// A regular file returns io.EOF as soon as it runs dry, unlike a pipe, so
+	// reading it exercises followReader's retry-after-EOF path.
+	w, err := os.CreateTemp(t.TempDir(), "follow")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	r, err := os.Open(w.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldPoll := followPollInterval
+	followPollInterval = time.Millisecond
+	defer func() { followPollInterval = oldPoll }()
+
+	fr := &followReader{ctx: ctx, r: r}
+	buf := make([]byte, 16)
+
+	readDone := make(chan struct{})
+	var n int
+	go func() {
+		n, err = fr.Read(buf)
+		close(readDone)
+	}()
+
+	select {
+	case <-readDone:
+		t.Fatalf("Read() returned before any data was written")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	w.Write([]byte("hello"))
+	<-readDone
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if string(buf[:n]) != "hello" {
+		t.Errorf("Read() = %q, want %q", buf[:n], "hello")
+	}
+
+	cancel()
+	w.Close()
+	if _, err := fr.Read(buf); err != io.EOF {
+		t.Errorf("Read() after ctx cancellation = %v, want io.EOF", err)
+	}
+}
+
+// TestFollowReaderUnblocksOnUnderlyingFileClose covers the case cancellation alone can't:
+// a Read already blocked inside the underlying file's Read syscall (a FIFO or pipe with a
+// silent writer, an interactive stdin). ctx being canceled doesn't reach into that syscall,
+// so main's SIGINT handler also closes the underlying *os.File directly; this confirms that
+// closing it is what actually unblocks a pending Read, independent of ctx.
+func TestFollowReaderUnblocksOnUnderlyingFileClose(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+
+	ctx := context.Background() // never canceled, mirroring main's reliance on closing the fd
+	fr := &followReader{ctx: ctx, r: r}
+	buf := make([]byte, 16)
+
+	readDone := make(chan struct{})
+	var readErr error
+	go func() {
+		_, readErr = fr.Read(buf)
+		close(readDone)
+	}()
+
+	select {
+	case <-readDone:
+		t.Fatalf("Read() returned before the file was closed")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("r.Close() error = %v", err)
+	}
+
+	select {
+	case <-readDone:
+	case <-time.After(time.Second):
+		t.Fatal("Read() did not return after the underlying file was closed")
+	}
+	if readErr == nil {
+		t.Errorf("Read() error = nil, want an error from the closed file")
+	}
+}
@@ -0,0 +1,257 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Selection carries the high/low/percentage options, plus the -sort-limit threshold, that
+// control which items a Formatter includes for each input result.
+type Selection struct {
+	High        int
+	Low         int
+	HighPercent float64
+	LowPercent  float64
+	SortLimit   int
+}
+
+// Formatter renders a set of per-input selection results to w.
+type Formatter interface {
+	Write(w io.Writer, results []inputResult, sel Selection) error
+}
+
+// formatterFor resolves a -format flag value to a Formatter, or returns an error for an
+// unrecognized name.
+func formatterFor(name string) (Formatter, error) {
+	switch name {
+	case "text":
+		return TextFormatter{}, nil
+	case "json":
+		return JSONFormatter{}, nil
+	case "ndjson":
+		return NDJSONFormatter{}, nil
+	case "csv":
+		return csvFormatter{comma: ','}, nil
+	case "tsv":
+		return csvFormatter{comma: '\t'}, nil
+	default:
+		return nil, fmt.Errorf("Error: unknown -format %q (want text, json, ndjson, csv, or tsv)", name)
+	}
+}
+
+// selectionResult is what applying a Selection to one inputResult's counts produces: the
+// highest and/or lowest items requested, or, when neither is requested, every item (sorted
+// unless the distinct-line count exceeds sel.SortLimit, in which case a warning is printed
+// to stderr and items is left in map iteration order).
+type selectionResult struct {
+	highest []item
+	lowest  []item
+	items   []item // populated only when neither highest nor lowest was requested
+}
+
+// selectItems applies sel to counts the same way regardless of output format: a bounded
+// heap selection for a single side (see boundedTopK/boundedBottomK), a full sort when both
+// sides are requested and together approach the total item count, or, when neither is
+// requested, every item (subject to sel.SortLimit).
+func selectItems(counts map[string]int, sel Selection) selectionResult {
+	totalItems := len(counts)
+	highCount, lowCount := determineCounts(totalItems, sel.High, sel.Low, sel.HighPercent, sel.LowPercent)
+	highCount = min(highCount, totalItems)
+	lowCount = min(lowCount, totalItems)
+
+	if highCount == 0 && lowCount == 0 {
+		if totalItems > sel.SortLimit {
+			fmt.Fprintf(os.Stderr, "Warning: %d distinct lines exceeds -sort-limit %d, printing unsorted counts\n", totalItems, sel.SortLimit)
+			return selectionResult{items: unsortedItems(counts)}
+		}
+		return selectionResult{items: sortedItems(counts)}
+	}
+
+	if highCount > 0 && lowCount > 0 && highCount+lowCount > totalItems/2 {
+		// The two selections together cover most of the item space, so a bounded
+		// heap buys little; sort once and slice both ends.
+		items := sortedItems(counts)
+		return selectionResult{highest: items[:highCount], lowest: items[len(items)-lowCount:]}
+	}
+
+	var res selectionResult
+	if highCount > 0 {
+		res.highest = boundedTopK(counts, highCount)
+	}
+	if lowCount > 0 {
+		res.lowest = boundedBottomK(counts, lowCount)
+	}
+	return res
+}
+
+// unsortedItems builds an item slice directly from counts, in whatever order Go's map
+// iteration yields, bypassing the full sort entirely.
+func unsortedItems(counts map[string]int) []item {
+	items := make([]item, 0, len(counts))
+	for value, count := range counts {
+		items = append(items, item{value, count})
+	}
+	return items
+}
+
+// TextFormatter reproduces sus's original human-readable output.
+type TextFormatter struct{}
+
+func (TextFormatter) Write(w io.Writer, results []inputResult, sel Selection) error {
+	for i, result := range results {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "=== Results for %s:\n", result.name)
+
+		res := selectItems(result.counts, sel)
+		if len(res.highest) > 0 {
+			writeFrequencyItems(w, res.highest, sel.HighPercent, "Highest")
+		}
+		if len(res.lowest) > 0 {
+			if len(res.highest) > 0 {
+				fmt.Fprintln(w)
+			}
+			writeFrequencyItems(w, res.lowest, sel.LowPercent, "Lowest")
+		}
+		for _, it := range res.items {
+			fmt.Fprintf(w, "%d %s\n", it.count, it.value)
+		}
+	}
+	return nil
+}
+
+// writeFrequencyItems prints a subset of items based on their frequency.
+// It's used to display either the highest or lowest frequency items.
+func writeFrequencyItems(w io.Writer, items []item, percent float64, label string) {
+	if percent > 0 {
+		fmt.Fprintf(w, "%s %.2f%% (%d) frequency items:\n", label, percent, len(items))
+	} else {
+		fmt.Fprintf(w, "%s %d frequency items:\n", label, len(items))
+	}
+	for _, it := range items {
+		fmt.Fprintf(w, "%d %s\n", it.count, it.value)
+	}
+}
+
+// jsonItem is one count/value pair in JSONFormatter's output.
+type jsonItem struct {
+	Count int    `json:"count"`
+	Value string `json:"value"`
+}
+
+// jsonResult is one input source's entry in JSONFormatter's output.
+type jsonResult struct {
+	Source  string     `json:"source"`
+	Highest []jsonItem `json:"highest,omitempty"`
+	Lowest  []jsonItem `json:"lowest,omitempty"`
+	Items   []jsonItem `json:"items,omitempty"`
+}
+
+// JSONFormatter renders results as a single JSON array, one object per input source (plus
+// an aggregate entry when -aggregate produced one), each carrying its "highest"/"lowest"/
+// "items" selection.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Write(w io.Writer, results []inputResult, sel Selection) error {
+	out := make([]jsonResult, len(results))
+	for i, result := range results {
+		res := selectItems(result.counts, sel)
+		out[i] = jsonResult{
+			Source:  result.name,
+			Highest: toJSONItems(res.highest),
+			Lowest:  toJSONItems(res.lowest),
+			Items:   toJSONItems(res.items),
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// NDJSONFormatter renders one JSON object per line, one line per selected item, so
+// streaming consumers can process results without buffering the whole response.
+type NDJSONFormatter struct{}
+
+// ndjsonRecord is one line of NDJSONFormatter's (and one row of csvFormatter's) output.
+type ndjsonRecord struct {
+	Source string `json:"source"`
+	Bucket string `json:"bucket"`
+	Rank   int    `json:"rank"`
+	Count  int    `json:"count"`
+	Value  string `json:"value"`
+}
+
+func (NDJSONFormatter) Write(w io.Writer, results []inputResult, sel Selection) error {
+	enc := json.NewEncoder(w)
+	for _, rec := range flattenResults(results, sel) {
+		if err := enc.Encode(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvFormatter renders results as delimiter-separated rows with a header, one row per
+// selected item. comma selects ',' for CSV or '\t' for TSV.
+type csvFormatter struct {
+	comma rune
+}
+
+func (f csvFormatter) Write(w io.Writer, results []inputResult, sel Selection) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = f.comma
+
+	if err := cw.Write([]string{"source", "rank", "bucket", "count", "value"}); err != nil {
+		return err
+	}
+	for _, rec := range flattenResults(results, sel) {
+		row := []string{rec.Source, fmt.Sprintf("%d", rec.Rank), rec.Bucket, fmt.Sprintf("%d", rec.Count), rec.Value}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// flattenResults applies sel to every result and flattens the highest/lowest/items
+// selections into a single rank-ordered record stream, shared by NDJSONFormatter and
+// csvFormatter.
+func flattenResults(results []inputResult, sel Selection) []ndjsonRecord {
+	var records []ndjsonRecord
+	for _, result := range results {
+		res := selectItems(result.counts, sel)
+		records = append(records, bucketRecords(result.name, "highest", res.highest)...)
+		records = append(records, bucketRecords(result.name, "lowest", res.lowest)...)
+		records = append(records, bucketRecords(result.name, "items", res.items)...)
+	}
+	return records
+}
+
+func bucketRecords(source, bucket string, items []item) []ndjsonRecord {
+	records := make([]ndjsonRecord, len(items))
+	for i, it := range items {
+		records[i] = ndjsonRecord{Source: source, Bucket: bucket, Rank: i + 1, Count: it.count, Value: it.value}
+	}
+	return records
+}
+
+func toJSONItems(items []item) []jsonItem {
+	if len(items) == 0 {
+		return nil
+	}
+	out := make([]jsonItem, len(items))
+	for i, it := range items {
+		out[i] = jsonItem{Count: it.count, Value: it.value}
+	}
+	return out
+}
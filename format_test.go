@@ -0,0 +1,177 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testResults() []inputResult {
+	return []inputResult{
+		{name: "test", counts: map[string]int{"line1": 3, "line2": 2, "line3": 1}},
+	}
+}
+
+func TestFormatterFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantT   Formatter
+		wantErr bool
+	}{
+		{"text", TextFormatter{}, false},
+		{"json", JSONFormatter{}, false},
+		{"ndjson", NDJSONFormatter{}, false},
+		{"csv", csvFormatter{comma: ','}, false},
+		{"tsv", csvFormatter{comma: '\t'}, false},
+		{"xml", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := formatterFor(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("formatterFor(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.wantT) {
+				t.Errorf("formatterFor(%q) = %#v, want %#v", tt.name, got, tt.wantT)
+			}
+		})
+	}
+}
+
+func TestJSONFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	sel := Selection{High: 2, SortLimit: defaultSortLimit}
+	if err := (JSONFormatter{}).Write(&buf, testResults(), sel); err != nil {
+		t.Fatalf("JSONFormatter.Write() error = %v", err)
+	}
+
+	var got []jsonResult
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, output = %s", err, buf.String())
+	}
+
+	want := []jsonResult{
+		{
+			Source:  "test",
+			Highest: []jsonItem{{3, "line1"}, {2, "line2"}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("JSONFormatter.Write() = %+v, want %+v", got, want)
+	}
+}
+
+func TestJSONFormatterNoSelection(t *testing.T) {
+	var buf bytes.Buffer
+	sel := Selection{SortLimit: defaultSortLimit}
+	if err := (JSONFormatter{}).Write(&buf, testResults(), sel); err != nil {
+		t.Fatalf("JSONFormatter.Write() error = %v", err)
+	}
+
+	var got []jsonResult
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v, output = %s", err, buf.String())
+	}
+	if len(got) != 1 || len(got[0].Items) != 3 {
+		t.Errorf("JSONFormatter.Write() = %+v, want one result with 3 items", got)
+	}
+}
+
+func TestNDJSONFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	sel := Selection{High: 1, Low: 1, SortLimit: defaultSortLimit}
+	if err := (NDJSONFormatter{}).Write(&buf, testResults(), sel); err != nil {
+		t.Fatalf("NDJSONFormatter.Write() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("NDJSONFormatter.Write() produced %d lines, want 2: %q", len(lines), buf.String())
+	}
+
+	var records []ndjsonRecord
+	for _, line := range lines {
+		var rec ndjsonRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("json.Unmarshal(%q) error = %v", line, err)
+		}
+		records = append(records, rec)
+	}
+
+	want := []ndjsonRecord{
+		{Source: "test", Bucket: "highest", Rank: 1, Count: 3, Value: "line1"},
+		{Source: "test", Bucket: "lowest", Rank: 1, Count: 1, Value: "line3"},
+	}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("NDJSONFormatter.Write() records = %+v, want %+v", records, want)
+	}
+}
+
+func TestCSVFormatter(t *testing.T) {
+	var buf bytes.Buffer
+	sel := Selection{High: 2, SortLimit: defaultSortLimit}
+	if err := (csvFormatter{comma: ','}).Write(&buf, testResults(), sel); err != nil {
+		t.Fatalf("csvFormatter.Write() error = %v", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("csv.ReadAll() error = %v", err)
+	}
+
+	want := [][]string{
+		{"source", "rank", "bucket", "count", "value"},
+		{"test", "1", "highest", "3", "line1"},
+		{"test", "2", "highest", "2", "line2"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("csvFormatter.Write() rows = %v, want %v", rows, want)
+	}
+}
+
+func TestTSVFormatterUsesTabs(t *testing.T) {
+	var buf bytes.Buffer
+	sel := Selection{High: 1, SortLimit: defaultSortLimit}
+	if err := (csvFormatter{comma: '\t'}).Write(&buf, testResults(), sel); err != nil {
+		t.Fatalf("csvFormatter.Write() error = %v", err)
+	}
+
+	r := csv.NewReader(&buf)
+	r.Comma = '\t'
+	rows, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("csv.ReadAll() error = %v", err)
+	}
+
+	want := [][]string{
+		{"source", "rank", "bucket", "count", "value"},
+		{"test", "1", "highest", "3", "line1"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("csvFormatter.Write() (tsv) rows = %v, want %v", rows, want)
+	}
+}
+
+// TestCSVFormatterQuotesValuesWithDelimiter verifies proper quoting of a value containing
+// the CSV delimiter itself, per encoding/csv's standard escaping rules.
+func TestCSVFormatterQuotesValuesWithDelimiter(t *testing.T) {
+	results := []inputResult{
+		{name: "test", counts: map[string]int{"a,b": 1}},
+	}
+
+	var buf bytes.Buffer
+	if err := (csvFormatter{comma: ','}).Write(&buf, results, Selection{SortLimit: defaultSortLimit}); err != nil {
+		t.Fatalf("csvFormatter.Write() error = %v", err)
+	}
+
+	if !strings.Contains(buf.String(), `"a,b"`) {
+		t.Errorf("csvFormatter.Write() output = %q, want quoted value containing the delimiter", buf.String())
+	}
+}
@@ -5,14 +5,17 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"os/signal"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 const (
@@ -23,6 +26,11 @@ Example usage:
   sus -high 5 -file input.txt
   cat input.txt | sus -low 10 -i
   sus -hp 10 -lp 5 -file file1.txt,file2.txt -aggregate`
+
+	// defaultSortLimit is the distinct-line threshold above which, when no
+	// high/low selection is requested, sus skips the full sort rather than
+	// paying its O(D log D) cost for output nobody bounded.
+	defaultSortLimit = 10000
 )
 
 // item represents a line of text and its frequency count.
@@ -50,6 +58,20 @@ func main() {
 	flagFiles := flag.String("file", "", "Input files separated by commas (optional)")
 	showVersion := flag.Bool("version", false, "Show version information")
 	verbose := flag.Bool("verbose", false, "Enable verbose logging")
+	sortLimit := flag.Int("sort-limit", defaultSortLimit, "When no -high/-low/-hp/-lp is set, print unsorted counts instead of paying full sort cost once distinct lines exceed this")
+	parallel := flag.Int("parallel", 0, "Shard a single large input across N worker goroutines (0 = auto, use GOMAXPROCS)")
+	fieldsFlag := flag.String("fields", "", "Select fields (1-based, e.g. 1,3-4) to form the counting key instead of the whole line")
+	delimFlag := flag.String("delim", "", "Field delimiter for -fields (default: runs of whitespace)")
+	var grepFlags, vgrepFlags regexpList
+	flag.Var(&grepFlags, "grep", "Keep only lines matching this regexp (repeatable; a line must match all of them)")
+	flag.Var(&vgrepFlags, "vgrep", "Drop lines matching this regexp (repeatable; a line is dropped if it matches any of them)")
+	formatFlag := flag.String("format", "text", "Output format: text, json, ndjson, csv, or tsv")
+	approxFlag := flag.Bool("approx", false, "Approximate -high with a Count-Min Sketch instead of exact counting, for input with very large cardinality")
+	epsilonFlag := flag.Float64("epsilon", 0.001, "Count-Min Sketch error bound (used with -approx)")
+	deltaFlag := flag.Float64("delta", 0.001, "Count-Min Sketch failure probability bound (used with -approx)")
+	followFlag := flag.Bool("follow", false, "Continuously read input (like tail -f), clearing the terminal and reprinting the top-N every -interval")
+	intervalFlag := flag.Duration("interval", 2*time.Second, "Refresh interval for -follow")
+	decayFlag := flag.Duration("decay", 0, "Half-life for decaying counts on each -follow refresh, so recent lines dominate (0 disables decay)")
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
@@ -72,14 +94,64 @@ func main() {
 		exitWithError(err)
 	}
 
+	formatter, err := formatterFor(*formatFlag)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	var approx *approxOptions
+	if *approxFlag {
+		if err := validateApproxFlags(*high, *low, *lowPercent, *epsilonFlag, *deltaFlag, *aggregate); err != nil {
+			exitWithError(err)
+		}
+		approx = &approxOptions{k: *high, epsilon: *epsilonFlag, delta: *deltaFlag}
+	}
+
+	var filter *filterOptions
+	if *fieldsFlag != "" || len(grepFlags.patterns) > 0 || len(vgrepFlags.patterns) > 0 {
+		fields, err := parseFieldSpec(*fieldsFlag)
+		if err != nil {
+			exitWithError(err)
+		}
+		filter = &filterOptions{fields: fields, delim: *delimFlag, grep: grepFlags.patterns, vgrep: vgrepFlags.patterns}
+	}
+
 	inputs, inputNames, cleanup, err := collectInputs(*flagFiles)
 	if err != nil {
 		exitWithError(err)
 	}
 	defer cleanup()
 
-	results := processInputs(inputs, inputNames, *ignoreCase, *aggregate, *verbose)
-	textOutput(results, *high, *low, *highPercent, *lowPercent)
+	if *followFlag {
+		if len(inputs) != 1 {
+			exitWithError(fmt.Errorf("Error: -follow requires exactly one input (stdin, or a single -file)"))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, os.Interrupt)
+		go func() {
+			<-sigCh
+			cancel()
+			// cancel alone only stops the scanner goroutine between reads; if it's
+			// currently blocked inside a Read that won't return on its own (a FIFO or
+			// pipe with a silent writer, an interactive stdin), closing the
+			// underlying file is what actually unblocks it.
+			if f, ok := inputs[0].(*os.File); ok {
+				f.Close()
+			}
+		}()
+
+		sel := Selection{High: *high, Low: *low, HighPercent: *highPercent, LowPercent: *lowPercent, SortLimit: *sortLimit}
+		runFollow(ctx, inputs[0], *ignoreCase, filter, followOptions{interval: *intervalFlag, decay: *decayFlag}, sel, formatter, os.Stdout)
+		return
+	}
+
+	results := processInputs(inputs, inputNames, *ignoreCase, *aggregate, *verbose, *parallel, filter, approx)
+	sel := Selection{High: *high, Low: *low, HighPercent: *highPercent, LowPercent: *lowPercent, SortLimit: *sortLimit}
+	if err := formatter.Write(os.Stdout, results, sel); err != nil {
+		exitWithError(fmt.Errorf("Error writing output: %v", err))
+	}
 }
 
 // validateFlags checks if the provided flag values are valid.
@@ -93,6 +165,31 @@ func validateFlags(high, low int, highPercent, lowPercent float64) error {
 	return nil
 }
 
+// validateApproxFlags checks that -approx is combined with a supported set of options. It
+// only tracks heavy hitters for a single pass, so it requires -high and rejects -low/-lp
+// (which would run boundedBottomK over an already-truncated map and report a bogus result)
+// and -aggregate (which would sum each input's independently-bounded top-K estimates
+// instead of tracking heavy hitters across the combined stream, silently dropping true
+// heavy hitters that didn't make every individual input's cut).
+func validateApproxFlags(high, low int, lowPercent, epsilon, delta float64, aggregate bool) error {
+	if high <= 0 {
+		return fmt.Errorf("Error: -approx requires -high to be set (it only tracks heavy hitters, not a bottom-K or a full ranking)")
+	}
+	if low > 0 || lowPercent > 0 {
+		return fmt.Errorf("Error: -approx does not support -low/-lp (it only tracks heavy hitters, not a bottom-K or a full ranking)")
+	}
+	if epsilon <= 0 || epsilon >= 1 {
+		return fmt.Errorf("Error: -epsilon must be between 0 and 1 (exclusive)")
+	}
+	if delta <= 0 || delta >= 1 {
+		return fmt.Errorf("Error: -delta must be between 0 and 1 (exclusive); ln(1/delta) must be positive to size the sketch's hash rows")
+	}
+	if aggregate {
+		return fmt.Errorf("Error: -approx -aggregate is not supported (each input's heavy-hitter set is already bounded to -high, so summing them loses heavy hitters that didn't make every input's cut)")
+	}
+	return nil
+}
+
 // collectInputs gathers input from specified files or stdin.
 // It returns slices of io.Readers and their names, a cleanup function, and any error encountered.
 func collectInputs(flagFiles string) ([]io.Reader, []string, func(), error) {
@@ -141,7 +238,7 @@ func collectInputs(flagFiles string) ([]io.Reader, []string, func(), error) {
 
 // processInputs processes multiple input sources, optionally aggregating results.
 // It returns a slice of inputResult structures containing the processed data.
-func processInputs(inputs []io.Reader, inputNames []string, ignoreCase, aggregate bool, verbose bool) []inputResult {
+func processInputs(inputs []io.Reader, inputNames []string, ignoreCase, aggregate bool, verbose bool, parallel int, filter *filterOptions, approx *approxOptions) []inputResult {
 	var wg sync.WaitGroup
 	resultsChan := make(chan inputResult, len(inputs))
 	aggregateCounts := make(map[string]int)
@@ -156,7 +253,7 @@ func processInputs(inputs []io.Reader, inputNames []string, ignoreCase, aggregat
 				fmt.Printf("Processing input: %s\n", name)
 			}
 
-			fileCounts := processInput(input, ignoreCase)
+			fileCounts := processInput(input, ignoreCase, parallel, filter, approx)
 			resultsChan <- inputResult{name, fileCounts}
 
 			if aggregate {
@@ -190,8 +287,26 @@ func processInputs(inputs []io.Reader, inputNames []string, ignoreCase, aggregat
 	return allResults
 }
 
-// processInput reads from the provided input and counts line frequencies.
-func processInput(input io.Reader, ignoreCase bool) map[string]int {
+// processInput reads from the provided input and counts line frequencies. If approx is set,
+// counting is approximate (see scanCountsApprox) regardless of parallel. Otherwise parallel
+// selects how many worker goroutines shard the input (see resolveParallelism for what 0
+// means); when it resolves to a single worker the input is scanned directly on the calling
+// goroutine.
+func processInput(input io.Reader, ignoreCase bool, parallel int, filter *filterOptions, approx *approxOptions) map[string]int {
+	if approx != nil {
+		return scanCountsApprox(input, ignoreCase, filter, *approx)
+	}
+	if workers := resolveParallelism(parallel); workers > 1 {
+		return processInputParallel(input, ignoreCase, workers, filter)
+	}
+	return scanCounts(input, ignoreCase, filter)
+}
+
+// scanCounts reads every line from input and counts line frequencies on the calling
+// goroutine. It is the core counting loop shared by processInput's single-worker path and
+// each worker spawned by processInputParallel. Each line is run through filter (pre-filter
+// regexps, then field selection) before the optional case-folding is applied.
+func scanCounts(input io.Reader, ignoreCase bool, filter *filterOptions) map[string]int {
 	counts := make(map[string]int)
 	scanner := bufio.NewScanner(input)
 	scanner.Buffer(make([]byte, maxLineLength), maxLineLength)
@@ -200,10 +315,14 @@ func processInput(input io.Reader, ignoreCase bool) map[string]int {
 		if len(line) > maxLineLength {
 			exitWithError(fmt.Errorf("Line exceeds maximum length of %d characters", maxLineLength))
 		}
+		key, keep := filter.apply(line)
+		if !keep {
+			continue
+		}
 		if ignoreCase {
-			line = strings.ToLower(line)
+			key = strings.ToLower(key)
 		}
-		counts[line]++
+		counts[key]++
 	}
 
 	if err := scanner.Err(); err != nil {
@@ -212,47 +331,15 @@ func processInput(input io.Reader, ignoreCase bool) map[string]int {
 	return counts
 }
 
-// textOutput prints the processed results to stdout in a human-readable format.
-// It handles both individual and aggregated results based on the specified options.
-func textOutput(results []inputResult, high, low int, highPercent, lowPercent float64) {
-	for i, result := range results {
-		if i > 0 {
-			fmt.Println()
-		}
-		fmt.Printf("=== Results for %s:\n", result.name)
-		printSortedResults(result.counts, high, low, highPercent, lowPercent)
-	}
-}
-
-// printSortedResults sorts and prints the line frequency results based on the specified criteria.
-// It can show the most frequent, least frequent, or a percentage-based selection of results.
-func printSortedResults(counts map[string]int, high, low int, highPercent, lowPercent float64) {
+// sortedItems builds the full, sorted item slice for counts. It is the fallback used when
+// a bounded heap selection isn't applicable.
+func sortedItems(counts map[string]int) []item {
 	items := make([]item, 0, len(counts))
 	for value, count := range counts {
 		items = append(items, item{value, count})
 	}
-
 	sortItems(items)
-
-	totalItems := len(items)
-	highCount, lowCount := determineCounts(totalItems, high, low, highPercent, lowPercent)
-
-	// Ensure highCount and lowCount do not exceed available items
-	highCount = min(highCount, totalItems)
-	lowCount = min(lowCount, totalItems)
-
-	if highCount > 0 {
-		printFrequencyItems(items[:highCount], highCount, highPercent, "Highest")
-	}
-	if lowCount > 0 {
-		if highCount > 0 {
-			fmt.Println()
-		}
-		printFrequencyItems(items[len(items)-lowCount:], lowCount, lowPercent, "Lowest")
-	}
-	if highCount == 0 && lowCount == 0 {
-		printAllItems(items)
-	}
+	return items
 }
 
 // sortItems sorts a slice of items in descending order of count.
@@ -286,27 +373,6 @@ func determineCounts(totalItems, high, low int, highPercent, lowPercent float64)
 	return highCount, lowCount
 }
 
-// printFrequencyItems prints a subset of items based on their frequency.
-// It's used to display either the highest or lowest frequency items.
-func printFrequencyItems(items []item, count int, percent float64, label string) {
-	if percent > 0 {
-		fmt.Printf("%s %.2f%% (%d) frequency items:\n", label, percent, count)
-	} else {
-		fmt.Printf("%s %d frequency items:\n", label, count)
-	}
-	for _, item := range items {
-		fmt.Printf("%d %s\n", item.count, item.value)
-	}
-}
-
-// printAllItems prints all items in the order they appear in the slice.
-// This is used when no specific high or low count is requested.
-func printAllItems(items []item) {
-	for _, item := range items {
-		fmt.Printf("%d %s\n", item.count, item.value)
-	}
-}
-
 // exitWithError prints an error message to stderr and exits the program with status code 1.
 func exitWithError(err error) {
 	fmt.Fprintln(os.Stderr, err)
@@ -2,7 +2,7 @@ package main
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"strings"
 	"testing"
 )
@@ -11,7 +11,7 @@ func BenchmarkProcessInput(b *testing.B) {
 	input := strings.NewReader("line1\nline2\nline1\nLINE1\n")
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		processInput(input, false)
+		processInput(input, false, 1, nil, nil)
 		input.Seek(0, 0) // Reset the reader for the next iteration
 	}
 }
@@ -40,20 +40,36 @@ func BenchmarkProcessLargeInput(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		processInput(input, false)
+		processInput(input, false, 0, nil, nil) // auto: shards across GOMAXPROCS workers
 		input.Seek(0, 0)
 	}
 }
 
-func BenchmarkPrintSortedResults(b *testing.B) {
+func BenchmarkProcessLargeInputSequential(b *testing.B) {
+	var sb strings.Builder
+	for i := 0; i < 10000; i++ {
+		sb.WriteString(fmt.Sprintf("line%d\n", i%100))
+	}
+	input := strings.NewReader(sb.String())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		processInput(input, false, 1, nil, nil)
+		input.Seek(0, 0)
+	}
+}
+
+func BenchmarkTextFormatterWrite(b *testing.B) {
 	counts := make(map[string]int)
 	for i := 0; i < 1000; i++ {
 		counts[fmt.Sprintf("line%d", i)] = i
 	}
+	results := []inputResult{{name: "bench", counts: counts}}
+	sel := Selection{High: 10, Low: 10, SortLimit: defaultSortLimit}
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		printSortedResults(counts, 10, 10, 0, 0)
+		(TextFormatter{}).Write(io.Discard, results, sel)
 	}
 }
 
@@ -73,11 +89,9 @@ func BenchmarkPercentageBased(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		oldStdout := os.Stdout
-		os.Stdout = nil
-		counts := processInput(input, false)
-		printSortedResults(counts, 0, 0, 1.0, 0) // Top 1%
-		os.Stdout = oldStdout
+		counts := processInput(input, false, 0, nil, nil)
+		results := []inputResult{{name: "bench", counts: counts}}
+		(TextFormatter{}).Write(io.Discard, results, Selection{HighPercent: 1.0, SortLimit: defaultSortLimit}) // Top 1%
 		input.Seek(0, 0)
 	}
 }
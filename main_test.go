@@ -65,7 +65,7 @@ func TestProcessInput(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := processInput(input, tt.ignoreCase)
+			got := processInput(input, tt.ignoreCase, 1, nil, nil)
 			if !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("processInput() = %v, want %v", got, tt.want)
 			}
@@ -175,7 +175,7 @@ func TestProcessInputs(t *testing.T) {
 	inputs := []io.Reader{input1, input2}
 	inputNames := []string{"input1", "input2"}
 
-	results := processInputs(inputs, inputNames, false, true, false)
+	results := processInputs(inputs, inputNames, false, true, false, 1, nil, nil)
 
 	if len(results) != 3 { // 2 individual results + 1 aggregate
 		t.Errorf("processInputs() got %d results, want 3", len(results))
@@ -195,59 +195,48 @@ func TestProcessInputs(t *testing.T) {
 }
 
 func TestTextOutput(t *testing.T) {
-	// Redirect stdout to capture output
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
 	results := []inputResult{
 		{name: "test", counts: map[string]int{"line1": 2, "line2": 1}},
 	}
 
-	textOutput(results, 2, 0, 0, 0)
-
-	// Restore stdout
-	w.Close()
-	os.Stdout = oldStdout
-
 	var buf bytes.Buffer
-	io.Copy(&buf, r)
+	sel := Selection{High: 2, SortLimit: defaultSortLimit}
+	if err := (TextFormatter{}).Write(&buf, results, sel); err != nil {
+		t.Fatalf("TextFormatter.Write() error = %v", err)
+	}
 	output := buf.String()
 
 	if !strings.Contains(output, "=== Results for test:") {
-		t.Errorf("textOutput() output doesn't contain expected header")
+		t.Errorf("TextFormatter.Write() output doesn't contain expected header")
 	}
 	if !strings.Contains(output, "2 line1") {
-		t.Errorf("textOutput() output doesn't contain expected count for line1")
+		t.Errorf("TextFormatter.Write() output doesn't contain expected count for line1")
 	}
 	if !strings.Contains(output, "1 line2") {
-		t.Errorf("textOutput() output doesn't contain expected count for line2")
+		t.Errorf("TextFormatter.Write() output doesn't contain expected count for line2")
 	}
 }
 
-func TestPrintSortedResults(t *testing.T) {
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
-	counts := map[string]int{"line1": 3, "line2": 2, "line3": 1}
-	printSortedResults(counts, 2, 0, 0, 0)
-
-	w.Close()
-	os.Stdout = oldStdout
+func TestTextFormatterSelection(t *testing.T) {
+	results := []inputResult{
+		{name: "test", counts: map[string]int{"line1": 3, "line2": 2, "line3": 1}},
+	}
 
 	var buf bytes.Buffer
-	io.Copy(&buf, r)
+	sel := Selection{High: 2, SortLimit: defaultSortLimit}
+	if err := (TextFormatter{}).Write(&buf, results, sel); err != nil {
+		t.Fatalf("TextFormatter.Write() error = %v", err)
+	}
 	output := buf.String()
 
 	if !strings.Contains(output, "3 line1") {
-		t.Errorf("printSortedResults() output doesn't contain expected count for line1")
+		t.Errorf("TextFormatter.Write() output doesn't contain expected count for line1")
 	}
 	if !strings.Contains(output, "2 line2") {
-		t.Errorf("printSortedResults() output doesn't contain expected count for line2")
+		t.Errorf("TextFormatter.Write() output doesn't contain expected count for line2")
 	}
 	if strings.Contains(output, "1 line3") {
-		t.Errorf("printSortedResults() output contains unexpected count for line3")
+		t.Errorf("TextFormatter.Write() output contains unexpected count for line3")
 	}
 }
 
@@ -293,30 +282,22 @@ func TestMainFunction(t *testing.T) {
 	}
 }
 
-func TestPrintAllItems(t *testing.T) {
-	oldStdout := os.Stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-
-	items := []item{
-		{"line1", 3},
-		{"line2", 2},
-		{"line3", 1},
+func TestTextFormatterNoSelection(t *testing.T) {
+	results := []inputResult{
+		{name: "test", counts: map[string]int{"line1": 3, "line2": 2, "line3": 1}},
 	}
 
-	printAllItems(items)
-
-	w.Close()
-	os.Stdout = oldStdout
-
 	var buf bytes.Buffer
-	io.Copy(&buf, r)
+	sel := Selection{SortLimit: defaultSortLimit}
+	if err := (TextFormatter{}).Write(&buf, results, sel); err != nil {
+		t.Fatalf("TextFormatter.Write() error = %v", err)
+	}
 	output := buf.String()
 
 	expectedLines := []string{"3 line1", "2 line2", "1 line3"}
 	for _, line := range expectedLines {
 		if !strings.Contains(output, line) {
-			t.Errorf("printAllItems() output doesn't contain expected line: %s", line)
+			t.Errorf("TextFormatter.Write() output doesn't contain expected line: %s", line)
 		}
 	}
 }
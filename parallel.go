@@ -0,0 +1,152 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// parallelChunkSize is the target size of each shard handed to a worker goroutine by
+// processInputParallel. It's a var rather than a const so tests can shrink it to exercise
+// multi-chunk sharding without generating megabytes of test data.
+var parallelChunkSize = 1 << 20 // ~1MB
+
+// resolveParallelism turns the -parallel flag value into a worker count. 0 or any
+// non-positive value means "auto", which uses GOMAXPROCS; anything else is used as-is.
+func resolveParallelism(parallel int) int {
+	if parallel > 0 {
+		return parallel
+	}
+	if n := runtime.GOMAXPROCS(0); n > 0 {
+		return n
+	}
+	return 1
+}
+
+// processInputParallel shards a single input across workers goroutines, splitting it into
+// ~parallelChunkSize chunks aligned to newline boundaries (see splitIntoChunks) so no line
+// is ever split across workers. Each worker counts its chunks into a local map, and the maps
+// are merged once every chunk has been processed. Output is identical to scanCounts
+// regardless of worker count.
+func processInputParallel(input io.Reader, ignoreCase bool, workers int, filter *filterOptions) map[string]int {
+	chunks := make(chan []byte, workers)
+	partials := make(chan map[string]int, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			partials <- countChunks(chunks, ignoreCase, filter)
+		}()
+	}
+
+	go func() {
+		defer close(chunks)
+		if err := splitIntoChunks(input, chunks); err != nil {
+			exitWithError(err)
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(partials)
+	}()
+
+	counts := make(map[string]int)
+	for local := range partials {
+		for line, count := range local {
+			counts[line] += count
+		}
+	}
+	return counts
+}
+
+// countChunks builds a local line-frequency map from the byte chunks received on chunks,
+// using the same scanning, filtering, and maxLineLength rules as scanCounts.
+func countChunks(chunks <-chan []byte, ignoreCase bool, filter *filterOptions) map[string]int {
+	local := make(map[string]int)
+	for chunk := range chunks {
+		scanner := bufio.NewScanner(bytes.NewReader(chunk))
+		scanner.Buffer(make([]byte, maxLineLength), maxLineLength)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if len(line) > maxLineLength {
+				exitWithError(fmt.Errorf("Line exceeds maximum length of %d characters", maxLineLength))
+			}
+			key, keep := filter.apply(line)
+			if !keep {
+				continue
+			}
+			if ignoreCase {
+				key = strings.ToLower(key)
+			}
+			local[key]++
+		}
+		if err := scanner.Err(); err != nil {
+			exitWithError(fmt.Errorf("Error reading input: %v", err))
+		}
+	}
+	return local
+}
+
+// splitIntoChunks reads input in parallelChunkSize blocks and sends each one to out, first
+// extending it past the end of the block to the next newline so a chunk boundary never
+// falls in the middle of a line. The final chunk may be shorter than parallelChunkSize and
+// need not end in a newline. The caller is responsible for closing out.
+func splitIntoChunks(input io.Reader, out chan<- []byte) error {
+	reader := bufio.NewReaderSize(input, parallelChunkSize)
+
+	for {
+		buf := make([]byte, parallelChunkSize)
+		n, err := io.ReadFull(reader, buf)
+		buf = buf[:n]
+
+		if err == nil {
+			rest, tooLong, rerr := readLineRemainder(reader)
+			buf = append(buf, rest...)
+			if tooLong {
+				return fmt.Errorf("Line exceeds maximum length of %d characters", maxLineLength)
+			}
+			if rerr != nil && rerr != io.EOF {
+				return fmt.Errorf("Error reading input: %v", rerr)
+			}
+		} else if err != io.EOF && err != io.ErrUnexpectedEOF {
+			return fmt.Errorf("Error reading input: %v", err)
+		}
+
+		if len(buf) > 0 {
+			out <- buf
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+	}
+}
+
+// readLineRemainder reads from reader up to and including the next newline, the same way
+// reader.ReadBytes('\n') would, except bounded to maxLineLength extra bytes so a line with
+// no terminator (malformed input, a truncated write, or simply a line over maxLineLength)
+// can't pull the rest of the stream into memory before the per-chunk scanner in countChunks
+// ever gets a chance to apply the same bound. Returns tooLong instead of an error so the
+// caller can report it with scanCounts's own "line too long" wording.
+func readLineRemainder(reader *bufio.Reader) (rest []byte, tooLong bool, err error) {
+	for len(rest) <= maxLineLength {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return rest, false, err
+		}
+		rest = append(rest, b)
+		if b == '\n' {
+			return rest, false, nil
+		}
+	}
+	return rest, true, nil
+}
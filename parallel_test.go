@@ -0,0 +1,107 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveParallelism(t *testing.T) {
+	if got := resolveParallelism(4); got != 4 {
+		t.Errorf("resolveParallelism(4) = %d, want 4", got)
+	}
+	if got := resolveParallelism(1); got != 1 {
+		t.Errorf("resolveParallelism(1) = %d, want 1", got)
+	}
+	if got := resolveParallelism(0); got < 1 {
+		t.Errorf("resolveParallelism(0) = %d, want >= 1", got)
+	}
+	if got := resolveParallelism(-3); got < 1 {
+		t.Errorf("resolveParallelism(-3) = %d, want >= 1", got)
+	}
+}
+
+func TestProcessInputParallelMatchesSequential(t *testing.T) {
+	var sb strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&sb, "line%d\n", i%137)
+	}
+	text := sb.String()
+
+	oldChunkSize := parallelChunkSize
+	parallelChunkSize = 256 // force many small chunks across workers
+	defer func() { parallelChunkSize = oldChunkSize }()
+
+	want := scanCounts(strings.NewReader(text), false, nil)
+
+	for _, workers := range []int{1, 2, 8} {
+		got := processInput(strings.NewReader(text), false, workers, nil, nil)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("processInput(parallel=%d) = %v, want %v", workers, got, want)
+		}
+	}
+}
+
+func TestProcessInputParallelIgnoreCase(t *testing.T) {
+	oldChunkSize := parallelChunkSize
+	parallelChunkSize = 8
+	defer func() { parallelChunkSize = oldChunkSize }()
+
+	input := "line1\nline2\nline1\nLINE1\n"
+	want := map[string]int{"line1": 3, "line2": 1}
+
+	got := processInput(strings.NewReader(input), true, 4, nil, nil)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("processInput(ignoreCase, parallel=4) = %v, want %v", got, want)
+	}
+}
+
+func TestSplitIntoChunksAlignsOnNewlines(t *testing.T) {
+	oldChunkSize := parallelChunkSize
+	parallelChunkSize = 5
+	defer func() { parallelChunkSize = oldChunkSize }()
+
+	input := "aa\nbb\ncc\ndd\nee"
+	chunks := make(chan []byte, 16)
+
+	if err := splitIntoChunks(strings.NewReader(input), chunks); err != nil {
+		t.Fatalf("splitIntoChunks() error = %v", err)
+	}
+	close(chunks)
+
+	var rebuilt strings.Builder
+	for chunk := range chunks {
+		rebuilt.Write(chunk)
+	}
+
+	if rebuilt.String() != input {
+		t.Errorf("splitIntoChunks() reassembled = %q, want %q", rebuilt.String(), input)
+	}
+}
+
+// TestSplitIntoChunksBoundsUnterminatedLine covers a line with no newline before the next
+// chunk boundary: without a bound, extending the chunk to the next newline would read
+// unboundedly far into the rest of the stream looking for one. It should instead fail fast
+// with the same "line too long" error scanCounts reports, once maxLineLength extra bytes
+// have been read without finding a newline.
+func TestSplitIntoChunksBoundsUnterminatedLine(t *testing.T) {
+	oldChunkSize := parallelChunkSize
+	parallelChunkSize = 5
+	defer func() { parallelChunkSize = oldChunkSize }()
+
+	input := "aa\nbb\n" + strings.Repeat("x", maxLineLength*4) // no trailing newline
+	chunks := make(chan []byte, 16)
+
+	err := splitIntoChunks(strings.NewReader(input), chunks)
+	close(chunks)
+	for range chunks {
+	}
+
+	if err == nil || !strings.Contains(err.Error(), "exceeds maximum length") {
+		t.Fatalf("splitIntoChunks() error = %v, want an \"exceeds maximum length\" error", err)
+	}
+}
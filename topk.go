@@ -0,0 +1,110 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import "container/heap"
+
+// itemHeap is a binary heap of items ordered the same way sortItems orders
+// them: by count, and for equal counts, lexicographically by value. Which
+// end of that ordering sits at the root is controlled by reverse — false
+// gives a min-heap (root is the weakest candidate, used to build a top-K
+// list), true gives a max-heap (root is the strongest candidate, used to
+// build a bottom-K list).
+type itemHeap struct {
+	items   []item
+	reverse bool
+}
+
+func (h *itemHeap) Len() int { return len(h.items) }
+
+func (h *itemHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if h.reverse {
+		a, b = b, a
+	}
+	if a.count != b.count {
+		return a.count < b.count
+	}
+	return a.value > b.value
+}
+
+func (h *itemHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *itemHeap) Push(x any) { h.items = append(h.items, x.(item)) }
+
+func (h *itemHeap) Pop() any {
+	old := h.items
+	n := len(old)
+	it := old[n-1]
+	h.items = old[:n-1]
+	return it
+}
+
+// boundedTopK returns the k highest-count items from counts, in the same
+// descending order sortItems would produce, without sorting every distinct
+// line. It keeps only a size-k min-heap of the current best candidates,
+// replacing the weakest one whenever a stronger item is seen, so the sort
+// stage costs O(D log k) time and O(k) space instead of O(D log D) and O(D).
+func boundedTopK(counts map[string]int, k int) []item {
+	if k <= 0 {
+		return nil
+	}
+	h := &itemHeap{items: make([]item, 0, k)}
+	for value, count := range counts {
+		if h.Len() < k {
+			heap.Push(h, item{value, count})
+			continue
+		}
+		root := h.items[0]
+		if count > root.count || (count == root.count && value < root.value) {
+			h.items[0] = item{value, count}
+			heap.Fix(h, 0)
+		}
+	}
+	return drainDescending(h)
+}
+
+// boundedBottomK returns the k lowest-count items from counts, in the same
+// order as the tail of a full descending sort by sortItems (highest count
+// of that group first, lowest last), using a size-k
+// max-heap of the weakest candidates seen so far.
+func boundedBottomK(counts map[string]int, k int) []item {
+	if k <= 0 {
+		return nil
+	}
+	h := &itemHeap{items: make([]item, 0, k), reverse: true}
+	for value, count := range counts {
+		if h.Len() < k {
+			heap.Push(h, item{value, count})
+			continue
+		}
+		root := h.items[0]
+		if count < root.count || (count == root.count && value > root.value) {
+			h.items[0] = item{value, count}
+			heap.Fix(h, 0)
+		}
+	}
+	items := drainDescending(h)
+	// drainDescending yields the heap's strongest-first order, which for a
+	// reversed (max-)heap means lowest count first; reverse it so the
+	// result reads highest-of-the-low-group first, matching the tail slice
+	// of a full descending sort.
+	for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+		items[i], items[j] = items[j], items[i]
+	}
+	return items
+}
+
+// drainDescending repeatedly pops h and returns the results in reverse of
+// the order popped: pops come out weakest-first, and each is placed from
+// the end of the slice backward, so for a min-heap (reverse == false) the
+// result is already a standard descending ranking.
+func drainDescending(h *itemHeap) []item {
+	n := h.Len()
+	out := make([]item, n)
+	for i := n - 1; i >= 0; i-- {
+		out[i] = heap.Pop(h).(item)
+	}
+	return out
+}
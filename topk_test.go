@@ -0,0 +1,148 @@
+// Copyright (c) 2024 Derek Jenkins
+// SPDX-License-Identifier: MIT
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBoundedTopK(t *testing.T) {
+	counts := map[string]int{
+		"apple":      3,
+		"banana":     2,
+		"cherry":     5,
+		"date":       1,
+		"elderberry": 4,
+	}
+
+	got := boundedTopK(counts, 3)
+	want := []item{
+		{"cherry", 5},
+		{"elderberry", 4},
+		{"apple", 3},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("boundedTopK() = %v, want %v", got, want)
+	}
+}
+
+func TestBoundedTopKTies(t *testing.T) {
+	counts := map[string]int{
+		"b": 3,
+		"c": 3,
+		"a": 3,
+		"d": 1,
+	}
+
+	got := boundedTopK(counts, 2)
+	want := []item{
+		{"a", 3},
+		{"b", 3},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("boundedTopK() with ties = %v, want %v", got, want)
+	}
+}
+
+func TestBoundedBottomK(t *testing.T) {
+	counts := map[string]int{
+		"apple":      3,
+		"banana":     2,
+		"cherry":     5,
+		"date":       1,
+		"elderberry": 4,
+	}
+
+	got := boundedBottomK(counts, 2)
+	want := []item{
+		{"banana", 2},
+		{"date", 1},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("boundedBottomK() = %v, want %v", got, want)
+	}
+}
+
+func TestBoundedBottomKTies(t *testing.T) {
+	counts := map[string]int{
+		"b": 3,
+		"c": 3,
+		"a": 3,
+		"d": 5,
+	}
+
+	got := boundedBottomK(counts, 2)
+	want := []item{
+		{"b", 3},
+		{"c", 3},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("boundedBottomK() with ties = %v, want %v", got, want)
+	}
+}
+
+// TestBoundedSelectionMatchesFullSort checks that the bounded heap selections agree with
+// slicing a fully sorted item list, across a range of k and dataset sizes.
+func TestBoundedSelectionMatchesFullSort(t *testing.T) {
+	counts := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		counts[fmt.Sprintf("line%03d", i)] = (i * 37) % 50
+	}
+
+	items := sortedItems(counts)
+
+	for _, k := range []int{0, 1, 5, 37, 200, 500} {
+		var wantHigh, wantLow []item
+		if k > 0 {
+			n := min(k, len(items))
+			wantHigh = items[:n]
+			wantLow = items[len(items)-n:]
+		}
+
+		if got := boundedTopK(counts, k); !reflect.DeepEqual(got, wantHigh) {
+			t.Errorf("boundedTopK(%d) = %v, want %v", k, got, wantHigh)
+		}
+		if got := boundedBottomK(counts, k); !reflect.DeepEqual(got, wantLow) {
+			t.Errorf("boundedBottomK(%d) = %v, want %v", k, got, wantLow)
+		}
+	}
+}
+
+func TestSelectItemsSortLimit(t *testing.T) {
+	oldStderr := os.Stderr
+	rErr, wErr, _ := os.Pipe()
+	os.Stderr = wErr
+
+	counts := map[string]int{"a": 1, "b": 2, "c": 3}
+	var outBuf bytes.Buffer
+	results := []inputResult{{name: "test", counts: counts}}
+	if err := (TextFormatter{}).Write(&outBuf, results, Selection{SortLimit: 2}); err != nil {
+		t.Fatalf("TextFormatter.Write() error = %v", err)
+	}
+
+	wErr.Close()
+	os.Stderr = oldStderr
+
+	var errBuf bytes.Buffer
+	io.Copy(&errBuf, rErr)
+
+	if !strings.Contains(errBuf.String(), "sort-limit") {
+		t.Errorf("selectItems() stderr = %q, want a sort-limit warning", errBuf.String())
+	}
+	for _, want := range []string{"1 a", "2 b", "3 c"} {
+		if !strings.Contains(outBuf.String(), want) {
+			t.Errorf("TextFormatter.Write() output %q missing %q", outBuf.String(), want)
+		}
+	}
+}